@@ -0,0 +1,16 @@
+package gocircuit
+
+// FailureKind categorizes a failed outcome so ReadyToTrip policies built on
+// top of Counts can weight failures differently instead of treating every
+// error the same.
+type FailureKind int
+
+const (
+	// FailureTransient marks an outcome that's likely to succeed on retry,
+	// such as a timeout, connection reset, or 5xx response. It's also the
+	// default kind used when no FailureClassifier is configured.
+	FailureTransient FailureKind = iota
+	// FailurePermanent marks an outcome that retrying is unlikely to fix,
+	// such as a validation error or 4xx response.
+	FailurePermanent
+)
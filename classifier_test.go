@@ -0,0 +1,59 @@
+package gocircuit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerIsSuccessful(t *testing.T) {
+	config := CircuitBreakerConfig{
+		FailureThreshold: 1,
+		ResetTimeout:     1 * time.Minute,
+		SuccessThreshold: 2,
+		OpenDuration:     1 * time.Minute,
+		IsSuccessful: func(err error) bool {
+			return err == nil || errors.Is(err, context.Canceled)
+		},
+	}
+	cb := NewCircuitBreaker(config, nil)
+
+	err := cb.Execute(func() error { return context.Canceled })
+	if err == nil {
+		t.Fatalf("expected Execute to propagate the action's error")
+	}
+	if cb.State() == StateOpen {
+		t.Errorf("expected context.Canceled to be treated as a non-failure, got %v", cb.State())
+	}
+}
+
+func TestCircuitBreakerFailureClassifier(t *testing.T) {
+	errPermanent := errors.New("validation error")
+	errTransient := errors.New("connection reset")
+
+	config := CircuitBreakerConfig{
+		ReadyToTrip: func(counts Counts) bool {
+			return counts.PermanentFailures >= 1
+		},
+		FailureClassifier: func(err error) FailureKind {
+			if errors.Is(err, errPermanent) {
+				return FailurePermanent
+			}
+			return FailureTransient
+		},
+		ResetTimeout: 1 * time.Minute,
+		OpenDuration: 1 * time.Minute,
+	}
+	cb := NewCircuitBreaker(config, nil)
+
+	_ = cb.Execute(func() error { return errTransient })
+	if cb.State() == StateOpen {
+		t.Fatalf("expected a transient failure to leave the circuit untripped, got %v", cb.State())
+	}
+
+	_ = cb.Execute(func() error { return errPermanent })
+	if cb.State() != StateOpen {
+		t.Errorf("expected a permanent failure to trip the circuit, got %v", cb.State())
+	}
+}
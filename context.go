@@ -0,0 +1,44 @@
+package gocircuit
+
+import "context"
+
+// ExecuteContext runs action through the Circuit Breaker with ctx, applying
+// CircuitBreakerConfig.InvocationTimeout as a per-call deadline when set.
+// action runs in its own goroutine, so once InvocationTimeout elapses (or
+// ctx is otherwise done), ExecuteContext records ctx's error as the call's
+// outcome (a failure unless IsSuccessful accepts it) and returns without
+// waiting for action to return — closing the gap where a
+// stuck downstream call would otherwise never report a failure at all.
+func (cb *CircuitBreaker) ExecuteContext(ctx context.Context, action func(context.Context) error) error {
+	generation, lastAttemptSince, halfOpenProbe, err := cb.beforeRequest()
+	if err != nil {
+		return err
+	}
+	if halfOpenProbe {
+		defer cb.releaseHalfOpenProbe(generation)
+	}
+
+	if cb.config.InvocationTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, cb.config.InvocationTimeout)
+		defer cancel()
+	}
+
+	return cb.finish(generation, runWithContext(ctx, action), lastAttemptSince)
+}
+
+// runWithContext runs action in its own goroutine and returns whichever
+// finishes first: action's own result, or ctx's cancellation/deadline.
+func runWithContext(ctx context.Context, action func(context.Context) error) error {
+	done := make(chan error, 1)
+	go func() {
+		done <- action(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,72 @@
+package gocircuit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerExecuteContextSuccess(t *testing.T) {
+	config := CircuitBreakerConfig{
+		FailureThreshold: 1,
+		ResetTimeout:     1 * time.Minute,
+		SuccessThreshold: 1,
+		OpenDuration:     1 * time.Minute,
+	}
+	cb := NewCircuitBreaker(config, nil)
+
+	err := cb.ExecuteContext(context.Background(), func(ctx context.Context) error {
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestCircuitBreakerExecuteContextTimeout(t *testing.T) {
+	config := CircuitBreakerConfig{
+		FailureThreshold:  1,
+		ResetTimeout:      1 * time.Minute,
+		SuccessThreshold:  1,
+		OpenDuration:      1 * time.Minute,
+		InvocationTimeout: 20 * time.Millisecond,
+	}
+	cb := NewCircuitBreaker(config, nil)
+
+	started := make(chan struct{})
+	err := cb.ExecuteContext(context.Background(), func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	<-started
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if cb.State() != StateOpen {
+		t.Errorf("expected the timeout to count as a failure and trip the circuit, got %v", cb.State())
+	}
+}
+
+func TestCircuitBreakerExecuteContextCancelled(t *testing.T) {
+	config := CircuitBreakerConfig{
+		FailureThreshold: 1,
+		ResetTimeout:     1 * time.Minute,
+		SuccessThreshold: 1,
+		OpenDuration:     1 * time.Minute,
+	}
+	cb := NewCircuitBreaker(config, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := cb.ExecuteContext(ctx, func(ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("expected context.Canceled, got %v", err)
+	}
+}
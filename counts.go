@@ -0,0 +1,46 @@
+package gocircuit
+
+// Counts holds the aggregated outcome counters a Circuit Breaker tracks,
+// for use by ReadyToTrip policies that need more than a raw consecutive
+// failure count to decide whether to trip.
+type Counts struct {
+	Requests             uint32
+	TotalSuccesses       uint32
+	TotalFailures        uint32
+	ConsecutiveSuccesses uint32
+	ConsecutiveFailures  uint32
+
+	// TransientFailures and PermanentFailures break TotalFailures down by
+	// FailureKind, as reported by CircuitBreakerConfig.FailureClassifier, so
+	// a ReadyToTrip policy can weight the two differently. When no
+	// FailureClassifier is configured, every failure counts as
+	// FailureTransient, so TransientFailures equals TotalFailures.
+	TransientFailures uint32
+	PermanentFailures uint32
+}
+
+// onSuccess records a successful outcome.
+func (c *Counts) onSuccess() {
+	c.Requests++
+	c.TotalSuccesses++
+	c.ConsecutiveSuccesses++
+	c.ConsecutiveFailures = 0
+}
+
+// onFailure records a failed outcome of the given kind.
+func (c *Counts) onFailure(kind FailureKind) {
+	c.Requests++
+	c.TotalFailures++
+	c.ConsecutiveFailures++
+	c.ConsecutiveSuccesses = 0
+	if kind == FailurePermanent {
+		c.PermanentFailures++
+	} else {
+		c.TransientFailures++
+	}
+}
+
+// clear resets the counters to zero.
+func (c *Counts) clear() {
+	*c = Counts{}
+}
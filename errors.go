@@ -0,0 +1,11 @@
+package gocircuit
+
+import "errors"
+
+// ErrOpenState is returned when the circuit is open and rejecting calls.
+var ErrOpenState = errors.New("gocircuit: circuit breaker is open")
+
+// ErrTooManyRequests is returned when the circuit is half-open and either
+// has already admitted as many probes as it allows or the call failed the
+// HalfOpenRetryProbability trial.
+var ErrTooManyRequests = errors.New("gocircuit: too many requests")
@@ -0,0 +1,86 @@
+package gocircuit
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+// TestCircuitBreakerConcurrentExecuteDoesNotTearState hammers a single
+// CircuitBreaker with concurrent successes and failures and checks that
+// Counts never drifts from the outcomes actually recorded, i.e. that no
+// completion was dropped or double-counted by a torn read/mutate of state
+// across goroutines.
+func TestCircuitBreakerConcurrentExecuteDoesNotTearState(t *testing.T) {
+	config := CircuitBreakerConfig{
+		FailureThreshold: 1000000, // high enough that the circuit never trips
+		ResetTimeout:     1 * time.Minute,
+		SuccessThreshold: 1000000,
+		OpenDuration:     1 * time.Minute,
+	}
+	cb := NewCircuitBreaker(config, nil)
+
+	const goroutines = 50
+	const callsPerGoroutine = 200
+
+	var wg sync.WaitGroup
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+		go func(id int) {
+			defer wg.Done()
+			for i := 0; i < callsPerGoroutine; i++ {
+				if (id+i)%2 == 0 {
+					_ = cb.Execute(func() error { return nil })
+				} else {
+					_ = cb.Execute(func() error { return errors.New("failure") })
+				}
+			}
+		}(g)
+	}
+	wg.Wait()
+
+	counts := cb.Counts()
+	want := uint32(goroutines * callsPerGoroutine)
+	if counts.Requests != want {
+		t.Errorf("expected Requests to account for every call, want %d, got %+v", want, counts)
+	}
+	if counts.TotalSuccesses+counts.TotalFailures != counts.Requests {
+		t.Errorf("successes and failures should add up to Requests, got %+v", counts)
+	}
+}
+
+// TestCircuitBreakerStaleGenerationReportDiscarded checks that a done
+// callback from Allow, reported after the circuit has already tripped into
+// a new generation, doesn't corrupt the new generation's Counts.
+func TestCircuitBreakerStaleGenerationReportDiscarded(t *testing.T) {
+	config := CircuitBreakerConfig{
+		FailureThreshold: 1,
+		ResetTimeout:     1 * time.Minute,
+		SuccessThreshold: 1,
+		OpenDuration:     1 * time.Minute,
+	}
+	cb := NewCircuitBreaker(config, nil)
+
+	// Admit a call on the fresh breaker (which moves it to half-open), but
+	// don't report its outcome yet.
+	done, err := cb.Allow()
+	if err != nil {
+		t.Fatalf("expected the call to be admitted, got %v", err)
+	}
+
+	// Trip the circuit via a second, independent call, which starts a new
+	// generation.
+	_ = cb.Execute(func() error { return errors.New("failure") })
+	if cb.State() != StateOpen {
+		t.Fatalf("expected the circuit to be open, got %v", cb.State())
+	}
+
+	before := cb.Counts()
+	done(true) // stale: belongs to the generation before the trip
+	after := cb.Counts()
+
+	if after != before {
+		t.Errorf("expected a stale generation's report to be discarded, counts changed from %+v to %+v", before, after)
+	}
+}
@@ -0,0 +1,16 @@
+package gocircuit
+
+// Execute runs action through cb and returns its typed result, so callers
+// that need to return a value (HTTP responses, DB rows, RPC replies) don't
+// have to smuggle it out through a captured closure variable. It is a thin
+// generic wrapper around CircuitBreaker.Execute and shares the same
+// state/count bookkeeping underneath.
+func Execute[T any](cb *CircuitBreaker, action func() (T, error)) (T, error) {
+	var result T
+	err := cb.Execute(func() error {
+		var actionErr error
+		result, actionErr = action()
+		return actionErr
+	})
+	return result, err
+}
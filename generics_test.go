@@ -0,0 +1,60 @@
+package gocircuit
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestExecuteGeneric(t *testing.T) {
+	config := CircuitBreakerConfig{
+		FailureThreshold:   2,
+		ResetTimeout:       1 * time.Minute,
+		SuccessThreshold:   2,
+		AutoCloseThreshold: 2,
+		AutoCloseDuration:  500 * time.Millisecond,
+		OpenDuration:       1 * time.Second,
+	}
+	cb := NewCircuitBreaker(config, nil)
+
+	t.Run("SuccessReturnsTypedValue", func(t *testing.T) {
+		result, err := Execute(cb, func() (int, error) {
+			return 42, nil
+		})
+		if err != nil {
+			t.Fatalf("expected no error, got %v", err)
+		}
+		if result != 42 {
+			t.Errorf("expected result 42, got %d", result)
+		}
+	})
+
+	t.Run("FailurePropagatesResultAndError", func(t *testing.T) {
+		result, err := Execute(cb, func() (string, error) {
+			return "partial", errors.New("failure")
+		})
+		if err == nil {
+			t.Errorf("expected error, got nil")
+		}
+		if result != "partial" {
+			t.Errorf("expected action result to be returned alongside the error, got %q", result)
+		}
+	})
+
+	t.Run("StateTransitionsStillApply", func(t *testing.T) {
+		cb := NewCircuitBreaker(CircuitBreakerConfig{
+			FailureThreshold: 1,
+			ResetTimeout:     1 * time.Minute,
+			SuccessThreshold: 2,
+			OpenDuration:     1 * time.Minute,
+		}, nil)
+
+		_, err := Execute(cb, func() (int, error) { return 0, errors.New("failure") })
+		if err == nil {
+			t.Errorf("expected error, got nil")
+		}
+		if cb.State() != StateOpen {
+			t.Errorf("expected circuit to be open after failure threshold, got %v", cb.State())
+		}
+	})
+}
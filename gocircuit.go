@@ -1,7 +1,7 @@
 package gocircuit
 
 import (
-	"sync/atomic"
+	"sync"
 	"time"
 )
 
@@ -16,107 +16,382 @@ const (
 
 // CircuitBreakerConfig holds the configuration options for the Circuit Breaker.
 type CircuitBreakerConfig struct {
-	FailureThreshold   int           // The threshold for consecutive failures required to trip the circuit
+	Name               string        // Identifies this breaker in observability output, when multiple breakers share a process
+	FailureThreshold   int           // The threshold for consecutive failures required to trip the circuit (used when ReadyToTrip is nil)
 	ResetTimeout       time.Duration // The duration after which the circuit transitions to half-open
 	SuccessThreshold   int           // The threshold for consecutive successes required to reset the circuit
 	AutoCloseThreshold int           // The threshold for consecutive successful executions required to auto-close the circuit
 	AutoCloseDuration  time.Duration // The duration after which the circuit automatically closes if threshold not met
 	OpenDuration       time.Duration // The duration for which the circuit remains open before transitioning to half-open
+
+	// ReadyToTrip, if set, decides whether the circuit should trip based on
+	// the rolling Counts observed in the current window, instead of the raw
+	// consecutive failure count. See FailureRatePolicy for a built-in,
+	// percentage-based policy.
+	ReadyToTrip func(counts Counts) bool
+
+	// Interval is the size of the rolling window Counts are aggregated over
+	// for ReadyToTrip. Zero disables windowing: Counts accumulate until the
+	// next state transition instead of aging out over time.
+	Interval time.Duration
+
+	// IsSuccessful decides whether action's returned error should count as
+	// a success. It defaults to err == nil, letting callers treat errors
+	// like context.Canceled, HTTP 4xx responses, or sql.ErrNoRows as
+	// non-failures instead of tripping the circuit.
+	IsSuccessful func(err error) bool
+
+	// FailureClassifier categorizes a failing outcome as FailureTransient or
+	// FailurePermanent, so a ReadyToTrip policy reading Counts.
+	// TransientFailures/PermanentFailures can weight the two differently.
+	// It only runs for outcomes IsSuccessful has already classified as
+	// failures, and defaults to FailureTransient when unset.
+	FailureClassifier func(err error) FailureKind
+
+	// OnStateChange, if set, is invoked synchronously every time the
+	// circuit transitions from one CircuitState to another. It's the place
+	// to emit metrics, structured logs, or alerts without polling State().
+	OnStateChange func(name string, from, to CircuitState)
+
+	// OnCounts, if set, is invoked synchronously with a Counts snapshot
+	// whenever Counts are reset (on Reset, and on every state transition,
+	// which also starts a fresh generation's Counts).
+	OnCounts func(counts Counts)
+
+	// MaxHalfOpenRequests caps how many calls are admitted while the
+	// circuit is half-open; calls beyond the cap are rejected with
+	// ErrTooManyRequests instead of hammering a recovering dependency.
+	// Zero means no cap.
+	MaxHalfOpenRequests int
+
+	// HalfOpenRetryProbability, if set to a value in (0, 1), admits calls
+	// while half-open via a Bernoulli trial instead of admitting every one.
+	// It composes with MaxHalfOpenRequests: a call must pass the trial
+	// before it's counted against the cap.
+	HalfOpenRetryProbability float64
+
+	// InvocationTimeout, if set, bounds how long ExecuteContext waits for
+	// action before treating the call as failed with context.DeadlineExceeded
+	// (subject to IsSuccessful/FailureClassifier), so a stuck downstream
+	// call can't bypass the breaker's failure accounting.
+	InvocationTimeout time.Duration
 }
 
 // CircuitBreaker represents a Circuit Breaker.
+//
+// Every field below mu other than config and fallbackFunc, which are set
+// once by NewCircuitBreaker and only read afterwards, is only ever read or
+// written while mu is held. There
+// is a single admission/completion path: beforeRequest decides whether to
+// admit a call and hands back the generation it was admitted under, and
+// complete folds in the outcome only if that generation still matches
+// cb.generation. generation increments on every state transition, so a call
+// that's still in flight when the circuit trips, resets, or moves on to a
+// new half-open batch reports against a generation nothing reads anymore,
+// instead of corrupting the new one's Counts.
 type CircuitBreaker struct {
-	state              int32
-	config             CircuitBreakerConfig // Configuration options
-	failureCount       int32
-	successCount       int32
-	lastAttempt        int64
-	autoCloseCount     int32
-	autoCloseStartTime int64
-	openStartTime      int64
-	fallbackFunc       func() error // Fallback function to execute on failure
+	mu     sync.Mutex
+	config CircuitBreakerConfig // Configuration options
+
+	state      CircuitState
+	generation uint64
+
+	counts Counts
+	window *rollingWindow
+
+	lastAttempt        time.Time
+	openStartTime      time.Time
+	autoCloseCount     int
+	autoCloseStartTime time.Time
+	halfOpenProbes     int // In-flight calls admitted while half-open, for MaxHalfOpenRequests
+
+	fallbackFunc func() error // Fallback function to execute on failure
 }
 
 // NewCircuitBreaker creates a new Circuit Breaker with the given configuration.
 func NewCircuitBreaker(config CircuitBreakerConfig, fallbackFunc func() error) *CircuitBreaker {
-	return &CircuitBreaker{
-		state:        int32(StateClosed),
+	cb := &CircuitBreaker{
+		state:        StateClosed,
 		config:       config,
 		fallbackFunc: fallbackFunc, // Fallback function to handle failures
 	}
+	if config.Interval > 0 {
+		cb.window = newRollingWindow(config.Interval)
+	}
+	return cb
+}
+
+// stateTransition records a setStateLocked call that actually changed state,
+// so its caller can fire OnStateChange/OnCounts after releasing mu instead
+// of from inside the locked section. A zero-value stateTransition (changed
+// == false) means no transition happened and there's nothing to fire.
+type stateTransition struct {
+	changed  bool
+	old, new CircuitState
+	snapshot Counts
+}
+
+// setStateLocked transitions the circuit to newState, bumping generation and
+// clearing Counts (and the rolling window, if configured) so a completion
+// from the old generation can no longer affect the new one's accounting.
+// It's a no-op if newState matches the current state. The caller is
+// responsible for passing the returned stateTransition to
+// fireStateChangeHooks once mu has been released. mu must be held.
+func (cb *CircuitBreaker) setStateLocked(newState CircuitState, now time.Time) stateTransition {
+	if cb.state == newState {
+		return stateTransition{}
+	}
+
+	old := cb.state
+	cb.state = newState
+	cb.generation++
+	cb.counts.clear()
+	if cb.window != nil {
+		cb.window = newRollingWindow(cb.config.Interval)
+	}
+	cb.halfOpenProbes = 0
+
+	switch newState {
+	case StateOpen:
+		cb.openStartTime = now
+		cb.lastAttempt = now
+	case StateClosed:
+		cb.autoCloseCount = 0
+		cb.autoCloseStartTime = time.Time{}
+	}
+
+	return stateTransition{changed: true, old: old, new: newState, snapshot: cb.snapshotLocked()}
+}
+
+// fireStateChangeHooks invokes OnStateChange/OnCounts for a stateTransition
+// returned by setStateLocked/applyTimeoutsLocked. The caller must not hold mu
+// when calling this, since either hook may legitimately call back into the
+// breaker (State, Counts, Execute, ...), and mu isn't reentrant.
+func (cb *CircuitBreaker) fireStateChangeHooks(t stateTransition) {
+	if !t.changed {
+		return
+	}
+	if cb.config.OnStateChange != nil {
+		cb.config.OnStateChange(cb.config.Name, t.old, t.new)
+	}
+	if cb.config.OnCounts != nil {
+		cb.config.OnCounts(t.snapshot)
+	}
+}
+
+// applyTimeoutsLocked advances the circuit past the Closed->HalfOpen and
+// Open->HalfOpen timeouts if now has moved past them. mu must be held.
+func (cb *CircuitBreaker) applyTimeoutsLocked(now time.Time) stateTransition {
+	if cb.state == StateClosed && now.Sub(cb.lastAttempt) > cb.config.ResetTimeout {
+		return cb.setStateLocked(StateHalfOpen, now)
+	}
+	if cb.state == StateOpen && now.Sub(cb.openStartTime) > cb.config.OpenDuration {
+		return cb.setStateLocked(StateHalfOpen, now)
+	}
+	return stateTransition{}
+}
+
+// readyToTrip decides whether counts should trip the circuit, falling back
+// to the legacy consecutive-failure threshold when no ReadyToTrip policy is
+// configured.
+func (cb *CircuitBreaker) readyToTrip(counts Counts) bool {
+	if cb.config.ReadyToTrip != nil {
+		return cb.config.ReadyToTrip(counts)
+	}
+	return counts.ConsecutiveFailures >= uint32(cb.config.FailureThreshold)
+}
+
+// isSuccessful reports whether err should count as a success, using
+// config.IsSuccessful when set and err == nil otherwise.
+func (cb *CircuitBreaker) isSuccessful(err error) bool {
+	if cb.config.IsSuccessful != nil {
+		return cb.config.IsSuccessful(err)
+	}
+	return err == nil
+}
+
+// classifyFailure categorizes a failing outcome using config.FailureClassifier
+// when set, and FailureTransient otherwise.
+func (cb *CircuitBreaker) classifyFailure(err error) FailureKind {
+	if cb.config.FailureClassifier != nil {
+		return cb.config.FailureClassifier(err)
+	}
+	return FailureTransient
+}
+
+// recordResultLocked updates Counts (and the rolling window, if configured)
+// with one outcome and returns the Counts snapshot ReadyToTrip should see.
+// mu must be held.
+func (cb *CircuitBreaker) recordResultLocked(success bool, kind FailureKind) Counts {
+	if success {
+		cb.counts.onSuccess()
+	} else {
+		cb.counts.onFailure(kind)
+	}
+
+	if cb.window != nil {
+		cb.window.advance(time.Now())
+		cb.window.record(success, kind)
+	}
+
+	return cb.snapshotLocked()
+}
+
+// snapshotLocked returns the current Counts, folding in the rolling window
+// aggregate when one is configured. mu must be held.
+func (cb *CircuitBreaker) snapshotLocked() Counts {
+	if cb.window == nil {
+		return cb.counts
+	}
+
+	windowed := cb.window.aggregate()
+	result := cb.counts
+	result.Requests = windowed.Requests
+	result.TotalSuccesses = windowed.TotalSuccesses
+	result.TotalFailures = windowed.TotalFailures
+	result.TransientFailures = windowed.TransientFailures
+	result.PermanentFailures = windowed.PermanentFailures
+	return result
+}
+
+// Counts returns a snapshot of the Circuit Breaker's current Counts. When a
+// rolling window is configured, it's advanced to the current time first, so
+// a read during an idle period still ages out outcomes that have fallen
+// outside Interval rather than reporting a snapshot stale relative to wall
+// clock.
+func (cb *CircuitBreaker) Counts() Counts {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	if cb.window != nil {
+		cb.window.advance(time.Now())
+	}
+	return cb.snapshotLocked()
 }
 
 // Execute attempts to execute an action using the Circuit Breaker.
 func (cb *CircuitBreaker) Execute(action func() error) error {
-	// Check if the Circuit Breaker is closed and it's time to transition to half-open state.
-	lastAttemptSince := time.Since(time.Unix(atomic.LoadInt64(&cb.lastAttempt), 0))
-	if atomic.LoadInt32(&cb.state) == int32(StateClosed) && lastAttemptSince > cb.config.ResetTimeout {
-		atomic.StoreInt32(&cb.state, int32(StateHalfOpen))
+	generation, lastAttemptSince, halfOpenProbe, err := cb.beforeRequest()
+	if err != nil {
+		return err
+	}
+	if halfOpenProbe {
+		defer cb.releaseHalfOpenProbe(generation)
 	}
 
-	// Handle open state duration.
-	if atomic.LoadInt32(&cb.state) == int32(StateOpen) {
-		openSince := time.Since(time.Unix(atomic.LoadInt64(&cb.openStartTime), 0))
-		if openSince > cb.config.OpenDuration {
-			atomic.StoreInt32(&cb.state, int32(StateHalfOpen))
+	// Execute the action.
+	return cb.finish(generation, action(), lastAttemptSince)
+}
+
+// finish records the outcome of an admitted call against generation and runs
+// the fallback on failure, shared by Execute and ExecuteContext.
+func (cb *CircuitBreaker) finish(generation uint64, actionErr error, lastAttemptSince time.Duration) error {
+	countsSuccess := cb.isSuccessful(actionErr)
+	kind := FailureTransient
+	if !countsSuccess {
+		kind = cb.classifyFailure(actionErr)
+	}
+	cb.complete(generation, countsSuccess, kind, lastAttemptSince)
+
+	if actionErr == nil {
+		return nil // Successful execution, no error returned
+	}
+
+	// Fallback mechanism: Execute the fallback function on failure.
+	if cb.fallbackFunc != nil {
+		if fallbackErr := cb.fallbackFunc(); fallbackErr != nil {
+			return fallbackErr
 		}
 	}
 
-	// Execute the action.
-	err := action()
+	return actionErr // Failed execution, return the error
+}
+
+// complete records the outcome of a call admitted by beforeRequest/Allow,
+// applying the same state transition / auto-close logic Execute has always
+// used. If generation no longer matches the circuit's current generation —
+// because it tripped, reset, or moved on to a new half-open batch while the
+// call was in flight — the report is discarded instead of corrupting the new
+// generation's Counts.
+func (cb *CircuitBreaker) complete(generation uint64, success bool, kind FailureKind, lastAttemptSince time.Duration) {
+	transition := cb.completeLocked(generation, success, kind, lastAttemptSince)
+	cb.fireStateChangeHooks(transition)
+}
+
+// completeLocked does the locked work for complete and returns whatever
+// stateTransition resulted, for the caller to fire once mu is released. mu
+// must not be held by the caller.
+func (cb *CircuitBreaker) completeLocked(generation uint64, success bool, kind FailureKind, lastAttemptSince time.Duration) stateTransition {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if generation != cb.generation {
+		return stateTransition{}
+	}
+
+	counts := cb.recordResultLocked(success, kind)
+	now := time.Now()
 
 	// Handle success.
-	if err == nil {
-		atomic.AddInt32(&cb.successCount, 1)
-		if atomic.LoadInt32(&cb.successCount) >= int32(cb.config.SuccessThreshold) {
-			if cb.autoCloseCount >= int32(cb.config.AutoCloseThreshold) {
-				cb.Reset()
+	if success {
+		if counts.ConsecutiveSuccesses >= uint32(cb.config.SuccessThreshold) {
+			if cb.autoCloseCount >= cb.config.AutoCloseThreshold {
+				return cb.setStateLocked(StateClosed, now)
 			} else if lastAttemptSince > cb.config.AutoCloseDuration {
 				// Auto close threshold not met, but auto close duration exceeded, close the circuit.
-				cb.Reset()
+				return cb.setStateLocked(StateClosed, now)
 			}
 		}
-		return nil // Successful execution, no error returned
+		return stateTransition{}
 	}
 
 	// Handle failure.
-	atomic.AddInt32(&cb.failureCount, 1)
-	if atomic.LoadInt32(&cb.failureCount) >= int32(cb.config.FailureThreshold) {
-		atomic.StoreInt32(&cb.state, int32(StateOpen))
-		atomic.StoreInt64(&cb.openStartTime, time.Now().Unix())
-		atomic.StoreInt64(&cb.lastAttempt, time.Now().Unix())
+	var transition stateTransition
+	if cb.readyToTrip(counts) {
+		transition = cb.setStateLocked(StateOpen, now)
 	} else {
 		// Reset the auto close count on each failure.
-		atomic.StoreInt32(&cb.autoCloseCount, 0)
-	}
-
-	// Fallback mechanism: Execute the fallback function on failure.
-	if cb.fallbackFunc != nil {
-		fallbackErr := cb.fallbackFunc()
-		if fallbackErr != nil {
-			return fallbackErr
-		}
+		cb.autoCloseCount = 0
 	}
 
 	// Handle auto close start time.
-	if atomic.LoadInt32(&cb.state) == int32(StateClosed) {
-		autoCloseStartTime := time.Now().Unix()
-		atomic.StoreInt64(&cb.autoCloseStartTime, autoCloseStartTime)
+	if cb.state == StateClosed {
+		cb.autoCloseStartTime = now
 	}
 
-	return err // Failed execution, return the error
+	return transition
 }
 
-// reset resets the Circuit Breaker to the closed state.
+// Reset resets the Circuit Breaker to the closed state, bumping generation
+// so a call already in flight reports against the old, now-discarded one.
 func (cb *CircuitBreaker) Reset() {
-	atomic.StoreInt32(&cb.failureCount, 0)
-	atomic.StoreInt32(&cb.successCount, 0)
-	atomic.StoreInt32(&cb.autoCloseCount, 0)
-	atomic.StoreInt32(&cb.state, int32(StateClosed))
-	atomic.StoreInt64(&cb.autoCloseStartTime, 0) // Reset auto close start time
+	cb.mu.Lock()
+	old := cb.state
+	cb.state = StateClosed
+	cb.generation++
+	cb.counts.clear()
+	if cb.window != nil {
+		cb.window = newRollingWindow(cb.config.Interval)
+	}
+	cb.halfOpenProbes = 0
+	cb.autoCloseCount = 0
+	cb.autoCloseStartTime = time.Time{}
+	snapshot := cb.snapshotLocked()
+	cb.mu.Unlock()
+
+	if old != StateClosed && cb.config.OnStateChange != nil {
+		cb.config.OnStateChange(cb.config.Name, old, StateClosed)
+	}
+	if cb.config.OnCounts != nil {
+		cb.config.OnCounts(snapshot)
+	}
 }
 
-// State returns the current state of the Circuit Breaker.
+// State returns the current state of the Circuit Breaker. The
+// Closed->HalfOpen/Open->HalfOpen timeouts are only evaluated when a call
+// goes through beforeRequest, so State reports whatever that last
+// evaluation left behind rather than reapplying it here.
 func (cb *CircuitBreaker) State() CircuitState {
-	return CircuitState(atomic.LoadInt32(&cb.state))
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+	return cb.state
 }
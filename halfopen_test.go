@@ -0,0 +1,80 @@
+package gocircuit
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerMaxHalfOpenRequests(t *testing.T) {
+	config := CircuitBreakerConfig{
+		FailureThreshold:    1,
+		ResetTimeout:        1 * time.Millisecond,
+		SuccessThreshold:    100,
+		OpenDuration:        1 * time.Millisecond,
+		MaxHalfOpenRequests: 2,
+	}
+	cb := NewCircuitBreaker(config, nil)
+
+	// Trip the circuit, then wait past OpenDuration so the next calls land
+	// in HalfOpen.
+	_ = cb.Execute(func() error { return errors.New("failure") })
+	time.Sleep(5 * time.Millisecond)
+
+	var admitted, rejected int32
+	var wg sync.WaitGroup
+	block := make(chan struct{})
+
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			done, err := cb.Allow()
+			if err != nil {
+				atomic.AddInt32(&rejected, 1)
+				return
+			}
+			atomic.AddInt32(&admitted, 1)
+			<-block
+			done(true)
+		}()
+	}
+
+	// Give the goroutines a moment to reach Allow before releasing them.
+	time.Sleep(20 * time.Millisecond)
+	close(block)
+	wg.Wait()
+
+	if admitted != 2 {
+		t.Errorf("expected exactly MaxHalfOpenRequests=2 admissions, got %d", admitted)
+	}
+	if rejected != 3 {
+		t.Errorf("expected the remaining 3 calls to be rejected, got %d", rejected)
+	}
+}
+
+func TestCircuitBreakerHalfOpenRetryProbabilityRejectsProbes(t *testing.T) {
+	config := CircuitBreakerConfig{
+		FailureThreshold:         1,
+		ResetTimeout:             1 * time.Millisecond,
+		SuccessThreshold:         1,
+		OpenDuration:             1 * time.Millisecond,
+		HalfOpenRetryProbability: 0.0000001,
+	}
+	cb := NewCircuitBreaker(config, nil)
+
+	_ = cb.Execute(func() error { return errors.New("failure") })
+	time.Sleep(5 * time.Millisecond)
+
+	rejections := 0
+	for i := 0; i < 20; i++ {
+		if _, err := cb.Allow(); errors.Is(err, ErrTooManyRequests) {
+			rejections++
+		}
+	}
+	if rejections == 0 {
+		t.Errorf("expected a near-zero retry probability to reject at least one of 20 probes")
+	}
+}
@@ -0,0 +1,111 @@
+package gocircuit
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerOnStateChange(t *testing.T) {
+	type transition struct {
+		name     string
+		from, to CircuitState
+	}
+	var transitions []transition
+
+	config := CircuitBreakerConfig{
+		Name:             "orders-api",
+		FailureThreshold: 1,
+		ResetTimeout:     1 * time.Minute,
+		SuccessThreshold: 2,
+		OpenDuration:     1 * time.Minute,
+		OnStateChange: func(name string, from, to CircuitState) {
+			transitions = append(transitions, transition{name, from, to})
+		},
+	}
+	cb := NewCircuitBreaker(config, nil)
+
+	_ = cb.Execute(func() error { return errors.New("failure") })
+
+	if len(transitions) == 0 {
+		t.Fatalf("expected at least one state transition to be reported")
+	}
+	last := transitions[len(transitions)-1]
+	if last.name != "orders-api" || last.to != StateOpen {
+		t.Errorf("expected the final transition to report (%q, _, StateOpen), got %+v", "orders-api", last)
+	}
+}
+
+func TestCircuitBreakerOnCounts(t *testing.T) {
+	var reported []Counts
+	config := CircuitBreakerConfig{
+		FailureThreshold: 1,
+		ResetTimeout:     1 * time.Minute,
+		SuccessThreshold: 1,
+		OpenDuration:     1 * time.Minute,
+		OnCounts: func(counts Counts) {
+			reported = append(reported, counts)
+		},
+	}
+	cb := NewCircuitBreaker(config, nil)
+
+	cb.Reset()
+	if len(reported) != 1 {
+		t.Fatalf("expected Reset to report one Counts snapshot, got %d", len(reported))
+	}
+	if reported[0].Requests != 0 {
+		t.Errorf("expected the reported snapshot to be cleared, got %+v", reported[0])
+	}
+}
+
+func TestCircuitBreakerOnStateChangeHookCanCallBack(t *testing.T) {
+	config := CircuitBreakerConfig{
+		FailureThreshold: 1,
+		ResetTimeout:     1 * time.Minute,
+		SuccessThreshold: 1,
+		OpenDuration:     1 * time.Minute,
+	}
+	var reportedState CircuitState
+	var cb *CircuitBreaker
+	config.OnStateChange = func(name string, from, to CircuitState) {
+		// A metrics/logging hook calling back into the breaker is the
+		// whole point of the callback; it must not deadlock against the
+		// mutex the transition that invoked it was holding.
+		reportedState = cb.State()
+	}
+	cb = NewCircuitBreaker(config, nil)
+
+	done := make(chan struct{})
+	go func() {
+		_ = cb.Execute(func() error { return errors.New("failure") })
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Execute did not return; OnStateChange likely deadlocked calling back into the breaker")
+	}
+
+	if reportedState != StateOpen {
+		t.Errorf("expected the hook's cb.State() call to observe StateOpen, got %v", reportedState)
+	}
+}
+
+func TestCircuitBreakerCounts(t *testing.T) {
+	config := CircuitBreakerConfig{
+		FailureThreshold: 5,
+		ResetTimeout:     1 * time.Minute,
+		SuccessThreshold: 5,
+		OpenDuration:     1 * time.Minute,
+	}
+	cb := NewCircuitBreaker(config, nil)
+
+	_ = cb.Execute(func() error { return nil })
+	_ = cb.Execute(func() error { return errors.New("failure") })
+
+	counts := cb.Counts()
+	if counts.Requests != 2 || counts.TotalSuccesses != 1 || counts.TotalFailures != 1 {
+		t.Errorf("unexpected Counts snapshot: %+v", counts)
+	}
+}
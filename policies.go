@@ -0,0 +1,16 @@
+package gocircuit
+
+// FailureRatePolicy returns a ReadyToTrip policy that trips once at least
+// minRequests outcomes have been observed in the current window and the
+// failure rate has reached failureRatio (a value between 0 and 1). This
+// mirrors the failure-threshold-percentage policies used by Mimir and
+// failsafe-go, and is steadier under bursty traffic than a raw consecutive
+// failure count.
+func FailureRatePolicy(minRequests uint32, failureRatio float64) func(Counts) bool {
+	return func(counts Counts) bool {
+		if counts.Requests < minRequests {
+			return false
+		}
+		return float64(counts.TotalFailures)/float64(counts.Requests) >= failureRatio
+	}
+}
@@ -0,0 +1,82 @@
+package gocircuit
+
+import "time"
+
+// rollingWindowBuckets is the number of time buckets a rolling window is
+// split into. CircuitBreakerConfig.Interval is divided evenly across them,
+// so each bucket covers Interval/rollingWindowBuckets of wall-clock time.
+const rollingWindowBuckets = 10
+
+// rollingWindow is a fixed-size ring of per-bucket Counts used to aggregate
+// outcomes over a trailing time window, rather than since the breaker's
+// last full reset. Advancing the ring clears buckets whose window has
+// fully elapsed, so old outcomes age out instead of accumulating forever.
+type rollingWindow struct {
+	bucketSpan int64 // bucket width, in nanoseconds
+	buckets    [rollingWindowBuckets]Counts
+	bucketID   [rollingWindowBuckets]int64 // which bucketSpan-sized slot each entry covers
+	head       int
+}
+
+// newRollingWindow builds a rolling window covering the given interval.
+func newRollingWindow(interval time.Duration) *rollingWindow {
+	span := int64(interval) / rollingWindowBuckets
+	if span <= 0 {
+		span = int64(interval)
+	}
+	return &rollingWindow{bucketSpan: span}
+}
+
+// advance rotates the ring so the head bucket covers now, clearing any
+// buckets whose window has elapsed since they were last written.
+func (w *rollingWindow) advance(now time.Time) {
+	if w.bucketSpan <= 0 {
+		return
+	}
+
+	current := now.UnixNano() / w.bucketSpan
+	head := w.bucketID[w.head]
+
+	switch {
+	case head == current:
+		return
+	case current-head >= rollingWindowBuckets:
+		// The whole window is stale; clear it and start fresh at current.
+		for i := range w.buckets {
+			w.buckets[i] = Counts{}
+			w.bucketID[i] = current
+		}
+		w.head = 0
+	default:
+		for b := head + 1; b <= current; b++ {
+			w.head = (w.head + 1) % rollingWindowBuckets
+			w.buckets[w.head] = Counts{}
+			w.bucketID[w.head] = b
+		}
+	}
+}
+
+// record adds one outcome to the active bucket.
+func (w *rollingWindow) record(success bool, kind FailureKind) {
+	b := &w.buckets[w.head]
+	if success {
+		b.onSuccess()
+	} else {
+		b.onFailure(kind)
+	}
+}
+
+// aggregate sums Requests/TotalSuccesses/TotalFailures (and their per-kind
+// breakdown) across the whole window. Consecutive counters aren't
+// meaningful once bucketed, so callers track those separately.
+func (w *rollingWindow) aggregate() Counts {
+	var total Counts
+	for _, b := range w.buckets {
+		total.Requests += b.Requests
+		total.TotalSuccesses += b.TotalSuccesses
+		total.TotalFailures += b.TotalFailures
+		total.TransientFailures += b.TransientFailures
+		total.PermanentFailures += b.PermanentFailures
+	}
+	return total
+}
@@ -0,0 +1,86 @@
+package gocircuit
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerFailureRatePolicy(t *testing.T) {
+	config := CircuitBreakerConfig{
+		ReadyToTrip:      FailureRatePolicy(4, 0.5),
+		Interval:         1 * time.Minute,
+		ResetTimeout:     1 * time.Minute,
+		SuccessThreshold: 2,
+		OpenDuration:     1 * time.Minute,
+	}
+	cb := NewCircuitBreaker(config, nil)
+
+	failAction := func() error { return errors.New("failure") }
+	successAction := func() error { return nil }
+
+	t.Run("StaysUntrippedBelowMinRequests", func(t *testing.T) {
+		_ = cb.Execute(failAction)
+		_ = cb.Execute(failAction)
+		_ = cb.Execute(failAction)
+
+		if cb.State() == StateOpen {
+			t.Errorf("expected circuit to stay untripped below the minimum request count, got %v", cb.State())
+		}
+	})
+
+	t.Run("TripsOnceFailureRateExceedsThreshold", func(t *testing.T) {
+		_ = cb.Execute(successAction)
+		_ = cb.Execute(failAction)
+
+		if cb.State() != StateOpen {
+			t.Errorf("expected circuit to open once the failure rate crossed 50%%, got %v", cb.State())
+		}
+	})
+}
+
+func TestCircuitBreakerCountsAgesOutDuringIdlePeriod(t *testing.T) {
+	config := CircuitBreakerConfig{
+		FailureThreshold: 100, // high enough that the one failure below doesn't trip and clear Counts itself
+		Interval:         50 * time.Millisecond,
+		ResetTimeout:     1 * time.Minute,
+		SuccessThreshold: 1,
+		OpenDuration:     1 * time.Minute,
+	}
+	cb := NewCircuitBreaker(config, nil)
+
+	_ = cb.Execute(func() error { return errors.New("failure") })
+	if counts := cb.Counts(); counts.Requests == 0 {
+		t.Fatalf("expected the failure to be recorded, got %+v", counts)
+	}
+
+	// No further traffic; just wait out several window intervals and read
+	// Counts directly, the way an OnCounts poller would.
+	time.Sleep(5 * config.Interval)
+
+	if counts := cb.Counts(); counts.Requests != 0 {
+		t.Errorf("expected Counts to age out the failure once idle past Interval, got %+v", counts)
+	}
+}
+
+func TestRollingWindowAggregate(t *testing.T) {
+	w := newRollingWindow(100 * time.Millisecond)
+	base := time.Unix(0, 0)
+
+	w.advance(base)
+	w.record(false, FailureTransient)
+	w.record(false, FailureTransient)
+	w.record(true, FailureTransient)
+
+	counts := w.aggregate()
+	if counts.Requests != 3 || counts.TotalFailures != 2 || counts.TotalSuccesses != 1 {
+		t.Fatalf("unexpected aggregate after initial writes: %+v", counts)
+	}
+
+	// Advancing well past the window should age out the old outcomes.
+	w.advance(base.Add(1 * time.Second))
+	counts = w.aggregate()
+	if counts.Requests != 0 {
+		t.Errorf("expected counts to age out once the window fully elapsed, got %+v", counts)
+	}
+}
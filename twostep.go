@@ -0,0 +1,112 @@
+package gocircuit
+
+import (
+	"math/rand"
+	"time"
+)
+
+// Allow asks the Circuit Breaker for permission to proceed, for callers that
+// can't naturally express their operation as a func() error — streaming
+// reads, request pipelines, or code that needs to inspect a response before
+// deciding success. It returns ErrOpenState when the circuit is open and
+// ErrTooManyRequests when it's half-open and has already admitted as many
+// probes as it allows or the call failed the HalfOpenRetryProbability
+// trial. On success it returns a done callback the caller must
+// invoke exactly once with the outcome of the operation it was permitted to
+// run, driving the same accounting as Execute. Since Allow's caller has no
+// error value to classify, a reported failure is always treated as
+// FailureTransient; use Execute/ExecuteContext if FailureClassifier matters.
+// This mirrors the two-step pattern from sony/gobreaker.
+func (cb *CircuitBreaker) Allow() (done func(success bool), err error) {
+	generation, lastAttemptSince, halfOpenProbe, err := cb.beforeRequest()
+	if err != nil {
+		return nil, err
+	}
+
+	return func(success bool) {
+		if halfOpenProbe {
+			defer cb.releaseHalfOpenProbe(generation)
+		}
+		cb.complete(generation, success, FailureTransient, lastAttemptSince)
+	}, nil
+}
+
+// beforeRequest applies the Closed->HalfOpen and Open->HalfOpen timeout
+// transitions and decides whether the circuit should admit the call,
+// returning ErrOpenState/ErrTooManyRequests if not. generation is the
+// circuit's generation at the moment of admission: complete and
+// releaseHalfOpenProbe discard a report naming a generation other than the
+// one cb is currently on, since a state transition in between means the
+// call's outcome no longer belongs to the current generation's Counts.
+// halfOpenProbe reports whether this admission was counted against
+// MaxHalfOpenRequests, so the caller knows to release it once the call
+// completes.
+func (cb *CircuitBreaker) beforeRequest() (generation uint64, lastAttemptSince time.Duration, halfOpenProbe bool, err error) {
+	var transition stateTransition
+	generation, lastAttemptSince, halfOpenProbe, err, transition = cb.beforeRequestLocked()
+	cb.fireStateChangeHooks(transition)
+	return generation, lastAttemptSince, halfOpenProbe, err
+}
+
+// beforeRequestLocked does the locked work for beforeRequest and returns
+// whatever stateTransition resulted, for the caller to fire once mu is
+// released. mu must not be held by the caller.
+func (cb *CircuitBreaker) beforeRequestLocked() (generation uint64, lastAttemptSince time.Duration, halfOpenProbe bool, err error, transition stateTransition) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	now := time.Now()
+	transition = cb.applyTimeoutsLocked(now)
+	lastAttemptSince = now.Sub(cb.lastAttempt)
+	generation = cb.generation
+
+	switch cb.state {
+	case StateOpen:
+		return generation, lastAttemptSince, false, ErrOpenState, transition
+	case StateHalfOpen:
+		halfOpenProbe, err = cb.admitHalfOpenProbeLocked()
+		if err != nil {
+			return generation, lastAttemptSince, false, err, transition
+		}
+	}
+
+	return generation, lastAttemptSince, halfOpenProbe, nil, transition
+}
+
+// admitHalfOpenProbeLocked applies HalfOpenRetryProbability and
+// MaxHalfOpenRequests gating to a call arriving while the circuit is
+// half-open, so a recovering dependency isn't hammered with every incoming
+// call the moment it flips out of Open. It reports whether it counted the
+// call against the in-flight probe limit, so the caller can release that
+// slot once the call completes. mu must be held.
+func (cb *CircuitBreaker) admitHalfOpenProbeLocked() (counted bool, err error) {
+	if p := cb.config.HalfOpenRetryProbability; p > 0 && p < 1 {
+		if rand.Float64() >= p {
+			return false, ErrTooManyRequests
+		}
+	}
+
+	if cb.config.MaxHalfOpenRequests <= 0 {
+		return false, nil
+	}
+
+	if cb.halfOpenProbes >= cb.config.MaxHalfOpenRequests {
+		return false, ErrTooManyRequests
+	}
+	cb.halfOpenProbes++
+	return true, nil
+}
+
+// releaseHalfOpenProbe decrements the in-flight half-open probe counter, as
+// long as generation still matches the circuit's current one. A mismatch
+// means the circuit has already moved past the half-open batch this probe
+// was admitted into, whose counter no longer exists to decrement.
+func (cb *CircuitBreaker) releaseHalfOpenProbe(generation uint64) {
+	cb.mu.Lock()
+	defer cb.mu.Unlock()
+
+	if generation != cb.generation || cb.halfOpenProbes <= 0 {
+		return
+	}
+	cb.halfOpenProbes--
+}
@@ -0,0 +1,48 @@
+package gocircuit
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCircuitBreakerAllow(t *testing.T) {
+	config := CircuitBreakerConfig{
+		FailureThreshold: 1,
+		ResetTimeout:     1 * time.Minute,
+		SuccessThreshold: 2,
+		OpenDuration:     1 * time.Minute,
+	}
+	cb := NewCircuitBreaker(config, nil)
+
+	t.Run("SuccessIsRecorded", func(t *testing.T) {
+		done, err := cb.Allow()
+		if err != nil {
+			t.Fatalf("expected the call to be admitted, got %v", err)
+		}
+		done(true)
+
+		if cb.State() == StateOpen {
+			t.Errorf("expected circuit to remain untripped after a success, got %v", cb.State())
+		}
+	})
+
+	t.Run("FailureTripsTheCircuit", func(t *testing.T) {
+		done, err := cb.Allow()
+		if err != nil {
+			t.Fatalf("expected the call to be admitted, got %v", err)
+		}
+		done(false)
+
+		if cb.State() != StateOpen {
+			t.Errorf("expected circuit to open after a reported failure, got %v", cb.State())
+		}
+	})
+
+	t.Run("OpenCircuitRejectsAllow", func(t *testing.T) {
+		_, err := cb.Allow()
+		if !errors.Is(err, ErrOpenState) {
+			t.Errorf("expected ErrOpenState while open, got %v", err)
+		}
+	})
+}